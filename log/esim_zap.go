@@ -0,0 +1,112 @@
+package log
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EsimZap wraps a *zap.Logger and keeps hold of the knobs (debug/json/level)
+// that were used to build it, so callers such as Elogger can reconfigure it
+// at runtime instead of rebuilding the whole logger tree.
+type EsimZap struct {
+	debug bool
+
+	json bool
+
+	atom zap.AtomicLevel
+
+	Logger *zap.Logger
+}
+
+type EsimZapOption func(ez *EsimZap)
+
+func NewEsimZap(options ...EsimZapOption) *EsimZap {
+	ez := &EsimZap{
+		atom: zap.NewAtomicLevelAt(zapcore.InfoLevel),
+	}
+
+	for _, option := range options {
+		option(ez)
+	}
+
+	if ez.debug {
+		ez.atom.SetLevel(zapcore.DebugLevel)
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	var encoder zapcore.Encoder
+	if ez.json {
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(encoderCfg)
+	}
+
+	core := zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), ez.atom)
+	ez.Logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2))
+
+	return ez
+}
+
+func WithEsimZapDebug(debug bool) EsimZapOption {
+	return func(ez *EsimZap) {
+		ez.debug = debug
+	}
+}
+
+func WithEsimZapJSON(json bool) EsimZapOption {
+	return func(ez *EsimZap) {
+		ez.json = json
+	}
+}
+
+// SetLevel atomically swaps the logging level. It's safe to call while other
+// goroutines are logging through the same EsimZap/Elogger.
+func (ez *EsimZap) SetLevel(lvl string) error {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(lvl)); err != nil {
+		return err
+	}
+
+	ez.atom.SetLevel(level)
+
+	return nil
+}
+
+// Level returns the current level as text, e.g. "debug", "info".
+func (ez *EsimZap) Level() string {
+	return ez.atom.Level().String()
+}
+
+type traceIDCtxKey struct{}
+
+// WithTraceID attaches a request/trace id to ctx so every ...c logging
+// call (Debugc, Infoc, ...), gormLogger, and the slog adapter pick it up
+// as a "trace_id" field without the caller having to pass it again.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDCtxKey{}, traceID)
+}
+
+// TraceIDFromCtx returns the trace id set by WithTraceID, if any.
+func TraceIDFromCtx(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(traceIDCtxKey{}).(string)
+	return traceID, ok && traceID != ""
+}
+
+// getArgs extracts request-scoped fields (trace id, etc) that should be
+// attached to every log line written with the Debugc/Infoc/... family.
+func (ez *EsimZap) getArgs(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+
+	if traceID, ok := TraceIDFromCtx(ctx); ok {
+		return []interface{}{"trace_id", traceID}
+	}
+
+	return nil
+}
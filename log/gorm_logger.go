@@ -0,0 +1,70 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	glogger "gorm.io/gorm/logger"
+)
+
+// gormLogger adapts an EsimZap to gorm's logger.Interface so SQL activity
+// goes through the same sinks (and the same atomic level) as the rest of
+// the application's logs.
+type gormLogger struct {
+	ez *EsimZap
+
+	slowThreshold time.Duration
+}
+
+type GLogOption func(g *gormLogger)
+
+func NewGormLogger(options ...GLogOption) glogger.Interface {
+	g := &gormLogger{
+		slowThreshold: 200 * time.Millisecond,
+	}
+
+	for _, option := range options {
+		option(g)
+	}
+
+	return g
+}
+
+func WithGLogEsimZap(ez *EsimZap) GLogOption {
+	return func(g *gormLogger) {
+		g.ez = ez
+	}
+}
+
+func (g *gormLogger) LogMode(glogger.LogLevel) glogger.Interface {
+	return g
+}
+
+func (g *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	g.ez.Logger.Sugar().With(g.ez.getArgs(ctx)...).Infof(msg, args...)
+}
+
+func (g *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	g.ez.Logger.Sugar().With(g.ez.getArgs(ctx)...).Warnf(msg, args...)
+}
+
+func (g *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	g.ez.Logger.Sugar().With(g.ez.getArgs(ctx)...).Errorf(msg, args...)
+}
+
+func (g *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+
+	sugar := g.ez.Logger.Sugar().With(g.ez.getArgs(ctx)...)
+
+	switch {
+	case err != nil && !errors.Is(err, glogger.ErrRecordNotFound):
+		sugar.Errorw("gorm trace", "sql", sql, "rows", rows, "elapsed", elapsed, "err", err)
+	case g.slowThreshold != 0 && elapsed > g.slowThreshold:
+		sugar.Warnw("slow sql", "sql", sql, "rows", rows, "elapsed", elapsed)
+	default:
+		sugar.Debugw("gorm trace", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler exposes the logger's level over HTTP: GET reads the current
+// level, PUT swaps it atomically. Mount it on the http-server module (e.g.
+// under "/debug/log/level") to let operators raise verbosity on the fly
+// without a restart.
+func LevelHandler(logger *Elogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(levelPayload{Level: logger.Level()})
+
+		case http.MethodPut:
+			var payload levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := logger.SetLevel(payload.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			json.NewEncoder(w).Encode(payload)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
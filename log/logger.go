@@ -77,6 +77,18 @@ func (log *Elogger) Glog() glogger.Interface {
 	return log.glog
 }
 
+// SetLevel atomically changes the log level, e.g. "debug", "info", "warn".
+// Existing Debugc/Infoc/... callers observe the new level immediately,
+// without needing to rebuild the logger or restart the process.
+func (log *Elogger) SetLevel(lvl string) error {
+	return log.ez.SetLevel(lvl)
+}
+
+// Level returns the current log level as text.
+func (log *Elogger) Level() string {
+	return log.ez.Level()
+}
+
 func (log *Elogger) Debugf(template string, args ...interface{}) {
 	log.sugar.With(log.ez.getArgs(context.TODO())...).Debugf(template, args...)
 }
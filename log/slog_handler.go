@@ -0,0 +1,109 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler adapts an EsimZap to the stdlib log/slog.Handler interface so
+// services on Go 1.21+ can log through slog while still routing every line
+// through EsimZap (and, transitively, the gorm logger) under the same
+// atomic level.
+type slogHandler struct {
+	ez *EsimZap
+
+	fields []zap.Field
+
+	groups []string
+}
+
+// NewSlogHandler wraps logger's EsimZap as a slog.Handler.
+func NewSlogHandler(logger *Elogger) slog.Handler {
+	return &slogHandler{ez: logger.ez}
+}
+
+// SlogLogger returns a *slog.Logger backed by logger's EsimZap.
+func SlogLogger(logger *Elogger) *slog.Logger {
+	return slog.New(NewSlogHandler(logger))
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.ez.atom.Enabled(slogToZapLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]zap.Field, 0, len(h.fields)+record.NumAttrs())
+	fields = append(fields, h.fields...)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = append(fields, h.attrToField(attr))
+		return true
+	})
+
+	ctxFields := h.ez.getArgs(ctx)
+	sugared := h.ez.Logger.With(fields...).Sugar()
+	if len(ctxFields) > 0 {
+		sugared = sugared.With(ctxFields...)
+	}
+
+	switch {
+	case record.Level >= slog.LevelError:
+		sugared.Error(record.Message)
+	case record.Level >= slog.LevelWarn:
+		sugared.Warn(record.Message)
+	case record.Level >= slog.LevelInfo:
+		sugared.Info(record.Message)
+	default:
+		sugared.Debug(record.Message)
+	}
+
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+
+	for _, attr := range attrs {
+		fields = append(fields, h.attrToField(attr))
+	}
+
+	return &slogHandler{ez: h.ez, fields: fields, groups: h.groups}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	groups := make([]string, 0, len(h.groups)+1)
+	groups = append(groups, h.groups...)
+	groups = append(groups, name)
+
+	return &slogHandler{ez: h.ez, fields: h.fields, groups: groups}
+}
+
+// attrToField flattens a (possibly grouped) slog.Attr into a dotted zap
+// field name, e.g. group "request" + attr "id" -> "request.id".
+func (h *slogHandler) attrToField(attr slog.Attr) zap.Field {
+	key := attr.Key
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		key = h.groups[i] + "." + key
+	}
+
+	return zap.Any(key, attr.Value.Any())
+}
+
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
@@ -0,0 +1,208 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jukylin/esim/log"
+	"gorm.io/gorm"
+)
+
+// cacheSkipKey is the gorm.Statement Settings key used to opt a single
+// call out of the cache, e.g. db.Set("cache:skip", true).
+const cacheSkipKey = "cache:skip"
+
+type cacheCtxKey string
+
+const cacheSkipCtxKey cacheCtxKey = cacheSkipKey
+
+var selectTablePattern = regexp.MustCompile(`(?i)from\s+` + "`?" + `(\w+)` + "`?")
+
+var writeTablePattern = regexp.MustCompile(`(?i)^\s*(?:insert\s+into|update|delete\s+from)\s+` + "`?" + `(\w+)` + "`?")
+
+// CacheProxy sits in the same chain as MonitorProxy (see ClientOptions.
+// WithProxy) and serves SELECT statements from a pluggable Cacher,
+// de-duplicating concurrent identical reads through an Easer so that N
+// callers asking for the same row collapse into a single round trip.
+type CacheProxy struct {
+	dbName string
+
+	cacher Cacher
+
+	easer *Easer
+
+	ttl time.Duration
+
+	logger log.Logger
+
+	next gormConnPoolLike
+}
+
+type CacheProxyOptions struct{}
+
+type CacheProxyOption func(p *CacheProxy)
+
+func (CacheProxyOptions) WithDbName(dbName string) CacheProxyOption {
+	return func(p *CacheProxy) {
+		p.dbName = dbName
+	}
+}
+
+func (CacheProxyOptions) WithCacher(cacher Cacher) CacheProxyOption {
+	return func(p *CacheProxy) {
+		p.cacher = cacher
+	}
+}
+
+func (CacheProxyOptions) WithTTL(ttl time.Duration) CacheProxyOption {
+	return func(p *CacheProxy) {
+		p.ttl = ttl
+	}
+}
+
+func (CacheProxyOptions) WithLogger(logger log.Logger) CacheProxyOption {
+	return func(p *CacheProxy) {
+		p.logger = logger
+	}
+}
+
+func NewCacheProxy(options ...CacheProxyOption) *CacheProxy {
+	p := &CacheProxy{
+		logger: log.NewLogger(),
+		easer:  NewEaser(),
+		ttl:    time.Minute,
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+func (p *CacheProxy) NextProxy(next interface{}) {
+	if connPool, ok := next.(gormConnPoolLike); ok {
+		p.next = connPool
+	}
+}
+
+func (p *CacheProxy) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.next.PrepareContext(ctx, query)
+}
+
+func (p *CacheProxy) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := p.next.ExecContext(ctx, query, args...)
+
+	if err == nil {
+		if table := writeTablePattern.FindStringSubmatch(query); len(table) == 2 {
+			if invalidateErr := p.cacher.Invalidate(ctx, table[1]); invalidateErr != nil {
+				p.logger.Errorc(ctx, "invalidate %s : %s", table[1], invalidateErr.Error())
+			}
+		}
+	}
+
+	return result, err
+}
+
+func (p *CacheProxy) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if bypassCache(ctx) || !isSelect(query) {
+		return p.next.QueryContext(ctx, query, args...)
+	}
+
+	table := "unknown"
+	if m := selectTablePattern.FindStringSubmatch(query); len(m) == 2 {
+		table = m[1]
+	}
+
+	key := cacheKey(p.dbName, table, query, args)
+
+	if val, found, err := p.cacher.Get(ctx, key); err == nil && found {
+		return decodeRows(val)
+	}
+
+	val, err, _ := p.easer.Do(key, func() (interface{}, error) {
+		rows, execErr := p.next.QueryContext(ctx, query, args...)
+		if execErr != nil {
+			return nil, execErr
+		}
+
+		encoded, encodeErr := encodeRows(rows)
+		if encodeErr != nil {
+			return nil, encodeErr
+		}
+
+		if storeErr := p.cacher.Store(ctx, key, encoded, p.ttl); storeErr != nil {
+			p.logger.Errorc(ctx, "store %s : %s", key, storeErr.Error())
+		}
+
+		return encoded, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRows(val.([]byte))
+}
+
+func (p *CacheProxy) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.next.QueryRowContext(ctx, query, args...)
+}
+
+func (p *CacheProxy) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return beginTx(p.next, ctx, opts)
+}
+
+// leadingCommentPattern strips a single "-- ..." or "/* ... */" comment
+// (and any whitespace around it) from the front of a query so isSelect
+// can see the statement keyword underneath it.
+var leadingCommentPattern = regexp.MustCompile(`(?s)^\s*(?:--[^\n]*\n|/\*.*?\*/)\s*`)
+
+// isSelect reports whether query is a read: a literal SELECT, or a
+// read-only CTE ("WITH ... SELECT ..."), optionally preceded by one
+// leading comment (a hint or an ORM-injected trace comment are both
+// common before either form). It doesn't attempt to look past more than
+// one comment, and a CTE feeding a non-SELECT final statement (e.g. "WITH
+// x AS (...) INSERT ...") is still treated as a read - MySQL itself
+// doesn't support writable CTEs, so that shape shouldn't occur in
+// practice.
+func isSelect(query string) bool {
+	trimmed := leadingCommentPattern.ReplaceAllString(query, "")
+	trimmed = strings.ToLower(strings.TrimSpace(trimmed))
+
+	return strings.HasPrefix(trimmed, "select") || strings.HasPrefix(trimmed, "with")
+}
+
+func bypassCache(ctx context.Context) bool {
+	skip, _ := ctx.Value(cacheSkipCtxKey).(bool)
+	return skip
+}
+
+// Register wires CacheProxy into db's callback chain: it hooks
+// Create/Update/Delete so a write through gorm's model API (rather than a
+// raw Exec) still busts the cache for the affected table, and it forwards
+// the per-call bypass tag (db.Set("cache:skip", true)) down into the
+// context so the ConnPool-level QueryContext can see it.
+func Register(db *gorm.DB, cacher Cacher) {
+	invalidate := func(tx *gorm.DB) {
+		if tx.Statement.Table == "" || tx.Error != nil {
+			return
+		}
+
+		if err := cacher.Invalidate(tx.Statement.Context, tx.Statement.Table); err != nil {
+			log.NewLogger().Errorc(tx.Statement.Context, "invalidate %s : %s", tx.Statement.Table, err.Error())
+		}
+	}
+
+	db.Callback().Create().After("gorm:create").Register("cache:invalidate_create", invalidate)
+	db.Callback().Update().After("gorm:update").Register("cache:invalidate_update", invalidate)
+	db.Callback().Delete().After("gorm:delete").Register("cache:invalidate_delete", invalidate)
+
+	db.Callback().Query().Before("gorm:query").Register("cache:forward_skip_tag", func(tx *gorm.DB) {
+		if skip, ok := tx.Get(cacheSkipKey); ok {
+			tx.Statement.Context = context.WithValue(tx.Statement.Context, cacheSkipCtxKey, skip)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingPool is a gormConnPoolLike stub that counts QueryContext calls
+// and answers every one with the same fixed rows, via the same fakeConnector
+// decodeRows uses to replay cached rows - that keeps this test exercising
+// the real encode/decode roundtrip rather than a hand-rolled *sql.Rows.
+type countingPool struct {
+	mu sync.Mutex
+
+	calls int
+
+	delay time.Duration
+
+	rows cachedRows
+}
+
+func (c *countingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("countingPool: PrepareContext not implemented")
+}
+
+func (c *countingPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errors.New("countingPool: ExecContext not implemented")
+}
+
+func (c *countingPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+
+	if c.delay > 0 {
+		time.Sleep(c.delay)
+	}
+
+	db := sql.OpenDB(fakeConnector{rows: c.rows})
+	defer db.Close()
+
+	return db.Query("SELECT 1")
+}
+
+func (c *countingPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *countingPool) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls
+}
+
+func encodeCachedRows(t *testing.T, cached cachedRows) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.Nil(t, gob.NewEncoder(&buf).Encode(cached))
+
+	return buf.Bytes()
+}
+
+func TestCacheProxy_CacheHit(t *testing.T) {
+	query := "select id, title from test where id = ?"
+	ctx := context.Background()
+
+	cacher := NewLRUCacher(10)
+	key := cacheKey("test_1", "test", query, []interface{}{1})
+	assert.Nil(t, cacher.Store(ctx, key, encodeCachedRows(t, cachedRows{
+		Columns: []string{"id", "title"},
+		Rows:    [][]driver.Value{{int64(1), "hello"}},
+	}), time.Minute))
+
+	next := &countingPool{}
+
+	cacheProxyOptions := CacheProxyOptions{}
+	p := NewCacheProxy(
+		cacheProxyOptions.WithDbName("test_1"),
+		cacheProxyOptions.WithCacher(cacher),
+	)
+	p.NextProxy(next)
+
+	rows, err := p.QueryContext(ctx, query, 1)
+	assert.Nil(t, err)
+	defer rows.Close()
+
+	assert.True(t, rows.Next())
+
+	var id int64
+	var title string
+	assert.Nil(t, rows.Scan(&id, &title))
+	assert.Equal(t, int64(1), id)
+	assert.Equal(t, "hello", title)
+
+	assert.Equal(t, 0, next.callCount())
+}
+
+func TestCacheProxy_ConcurrentReadsCollapseIntoOneDBCall(t *testing.T) {
+	query := "select id, title from test where id = ?"
+	ctx := context.Background()
+
+	next := &countingPool{
+		delay: 20 * time.Millisecond,
+		rows: cachedRows{
+			Columns: []string{"id", "title"},
+			Rows:    [][]driver.Value{{int64(1), "hello"}},
+		},
+	}
+
+	cacheProxyOptions := CacheProxyOptions{}
+	p := NewCacheProxy(
+		cacheProxyOptions.WithDbName("test_1"),
+		cacheProxyOptions.WithCacher(NewLRUCacher(10)),
+	)
+	p.NextProxy(next)
+
+	const n = 20
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+
+			rows, err := p.QueryContext(ctx, query, 1)
+			assert.Nil(t, err)
+			if rows != nil {
+				rows.Close()
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, 1, next.callCount())
+}
+
+func TestCacheProxy_SkipBypassesCache(t *testing.T) {
+	query := "select id, title from test where id = ?"
+	ctx := context.WithValue(context.Background(), cacheSkipCtxKey, true)
+
+	cacher := NewLRUCacher(10)
+	next := &countingPool{
+		rows: cachedRows{
+			Columns: []string{"id", "title"},
+			Rows:    [][]driver.Value{{int64(1), "hello"}},
+		},
+	}
+
+	cacheProxyOptions := CacheProxyOptions{}
+	p := NewCacheProxy(
+		cacheProxyOptions.WithDbName("test_1"),
+		cacheProxyOptions.WithCacher(cacher),
+	)
+	p.NextProxy(next)
+
+	for i := 0; i < 2; i++ {
+		rows, err := p.QueryContext(ctx, query, 1)
+		assert.Nil(t, err)
+		rows.Close()
+	}
+
+	assert.Equal(t, 2, next.callCount())
+
+	key := cacheKey("test_1", "test", query, []interface{}{1})
+	_, found, err := cacher.Get(context.Background(), key)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cacher stores the serialized result of a SELECT keyed by the statement
+// and its bound args, and invalidates by table once a write lands on it.
+type Cacher interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	Store(ctx context.Context, key string, val []byte, ttl time.Duration) error
+
+	Invalidate(ctx context.Context, tables ...string) error
+}
+
+// cacheKey derives a stable cache key from the db name, the table the
+// query targets, and a hash of the rendered SQL plus its bound args.
+func cacheKey(dbName, table, query string, args []interface{}) string {
+	h := sha1.New()
+	h.Write([]byte(query))
+
+	for _, arg := range args {
+		h.Write([]byte(argToBytes(arg)))
+	}
+
+	return dbName + ":" + table + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// tableFromCacheKey recovers the table segment cacheKey embedded in key
+// ("dbName:table:hash"), so a Cacher backend that tracks keys per table
+// (RedisCacher) doesn't need its own copy of the table passed around
+// separately. Returns "" if key isn't in that shape.
+func tableFromCacheKey(key string) string {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+func argToBytes(arg interface{}) string {
+	switch v := arg.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,302 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jukylin/esim/config"
+	"github.com/jukylin/esim/log"
+	"github.com/prometheus/client_golang/prometheus"
+	mysqldriver "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// DbConfig describes a single logical database: how to dial it and the
+// pool limits to apply once connected.
+type DbConfig struct {
+	Db string
+
+	Dsn string
+
+	MaxIdle int
+
+	MaxOpen int
+
+	// ReplicaDsns, when non-empty, turns on read/write splitting for this
+	// DbConfig: SELECTs are routed to a replica chosen by ReplicaPolicy,
+	// everything else sticks to Dsn (the primary).
+	ReplicaDsns []string
+
+	// ReplicaPolicy picks the load-balancing policy across ReplicaDsns.
+	// Defaults to PolicyRoundRobin.
+	ReplicaPolicy replicaPolicy
+
+	// ReplicaHealthCheck controls how often replicas are pinged. Defaults
+	// to 5s.
+	ReplicaHealthCheck time.Duration
+}
+
+// Client owns one *gorm.DB per configured DbConfig, built once and shared
+// for the lifetime of the process.
+type Client struct {
+	mu sync.RWMutex
+
+	dbConfigs []DbConfig
+
+	conf config.Config
+
+	gormConfig *gorm.Config
+
+	proxyFuncs []func() interface{}
+
+	stateTicker time.Duration
+
+	logger log.Logger
+
+	sqlDbs map[string]*sql.DB
+
+	gdbs map[string]*gorm.DB
+
+	replicaPools map[string]*replicaPool
+
+	closeCh chan struct{}
+}
+
+var clientOnce sync.Once
+
+var singleClient *Client
+
+var mysqlStats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "esim",
+	Subsystem: "mysql",
+	Name:      "stats",
+	Help:      "database/sql.DBStats collected per configured db",
+}, []string{"db", "stats"})
+
+func init() {
+	prometheus.MustRegister(mysqlStats)
+}
+
+type ClientOptions struct{}
+
+type ClientOption func(c *Client)
+
+func (ClientOptions) WithDbConfig(dbConfigs []DbConfig) ClientOption {
+	return func(c *Client) {
+		c.dbConfigs = dbConfigs
+	}
+}
+
+func (ClientOptions) WithConf(conf config.Config) ClientOption {
+	return func(c *Client) {
+		c.conf = conf
+	}
+}
+
+func (ClientOptions) WithGormConfig(gormConfig *gorm.Config) ClientOption {
+	return func(c *Client) {
+		c.gormConfig = gormConfig
+	}
+}
+
+// WithProxy registers a chain of proxy factories. Each factory is invoked
+// once per physical connection so proxies stay per-connection-pool, and
+// the proxies are chained together via NextProxy in the order supplied
+// here, with the last one wrapping the real *sql.DB.
+func (ClientOptions) WithProxy(proxyFuncs ...func() interface{}) ClientOption {
+	return func(c *Client) {
+		c.proxyFuncs = proxyFuncs
+	}
+}
+
+func (ClientOptions) WithStateTicker(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.stateTicker = d
+	}
+}
+
+// NewClient builds the singleton Client on first call; later calls just
+// return the existing instance, options included.
+func NewClient(options ...ClientOption) *Client {
+	clientOnce.Do(func() {
+		singleClient = &Client{
+			logger:       log.NewLogger(),
+			sqlDbs:       make(map[string]*sql.DB),
+			gdbs:         make(map[string]*gorm.DB),
+			replicaPools: make(map[string]*replicaPool),
+			closeCh:      make(chan struct{}),
+			stateTicker:  10 * time.Second,
+		}
+
+		for _, option := range options {
+			option(singleClient)
+		}
+
+		singleClient.init()
+	})
+
+	return singleClient
+}
+
+func (c *Client) init() {
+	for _, dbConfig := range c.dbConfigs {
+		sqlDb, err := sql.Open("mysql", dbConfig.Dsn)
+		if err != nil {
+			c.logger.Fatalf("open %s : %s", dbConfig.Db, err.Error())
+		}
+
+		sqlDb.SetMaxIdleConns(dbConfig.MaxIdle)
+		sqlDb.SetMaxOpenConns(dbConfig.MaxOpen)
+
+		c.sqlDbs[dbConfig.Db] = sqlDb
+
+		gormConfig := c.gormConfig
+		if gormConfig == nil {
+			gormConfig = &gorm.Config{}
+		}
+
+		connPool := gorm.ConnPool(c.wrapConnPool(sqlDb))
+
+		if len(dbConfig.ReplicaDsns) > 0 {
+			pool := c.newReplicaPool(dbConfig)
+			c.replicaPools[dbConfig.Db] = pool
+			connPool = &replicaRouter{write: connPool, pool: pool}
+		}
+
+		gdb, err := gorm.Open(mysqldriver.New(mysqldriver.Config{
+			Conn: connPool,
+		}), gormConfig)
+		if err != nil {
+			c.logger.Fatalf("gorm.Open %s : %s", dbConfig.Db, err.Error())
+		}
+
+		c.gdbs[dbConfig.Db] = gdb
+	}
+
+	go c.collectStats()
+}
+
+// newReplicaPool dials every replica in dbConfig, wrapping each one in its
+// own proxy chain just like the primary, and starts its health checker.
+func (c *Client) newReplicaPool(dbConfig DbConfig) *replicaPool {
+	replicas := make([]*replica, 0, len(dbConfig.ReplicaDsns))
+
+	for i, dsn := range dbConfig.ReplicaDsns {
+		sqlDb, err := sql.Open("mysql", dsn)
+		if err != nil {
+			c.logger.Fatalf("open replica %d of %s : %s", i, dbConfig.Db, err.Error())
+		}
+
+		sqlDb.SetMaxIdleConns(dbConfig.MaxIdle)
+		sqlDb.SetMaxOpenConns(dbConfig.MaxOpen)
+
+		replicas = append(replicas, &replica{
+			instance: fmt.Sprintf("replica-%d", i),
+			sqlDb:    sqlDb,
+			connPool: c.wrapConnPool(sqlDb),
+			healthy:  1,
+		})
+	}
+
+	pool := newReplicaPool(dbConfig.Db, dbConfig.ReplicaPolicy, replicas, c.logger)
+
+	healthCheckInterval := dbConfig.ReplicaHealthCheck
+	if healthCheckInterval == 0 {
+		healthCheckInterval = 5 * time.Second
+	}
+
+	go pool.healthCheck(healthCheckInterval)
+
+	return pool
+}
+
+// wrapConnPool chains the configured proxies in front of sqlDb, last
+// factory closest to the driver, first factory closest to gorm. It's
+// called once per physical connection - the primary and, when read/write
+// splitting is on, each replica - so every pool gets its own proxy chain.
+func (c *Client) wrapConnPool(sqlDb *sql.DB) gorm.ConnPool {
+	var connPool gorm.ConnPool = sqlDb
+
+	for i := len(c.proxyFuncs) - 1; i >= 0; i-- {
+		proxy, ok := c.proxyFuncs[i]().(Proxy)
+		if !ok {
+			continue
+		}
+
+		proxy.NextProxy(connPool)
+		connPool = proxy
+	}
+
+	return connPool
+}
+
+func (c *Client) collectStats() {
+	ticker := time.NewTicker(c.stateTicker)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.RLock()
+			for name, sqlDb := range c.sqlDbs {
+				stats := sqlDb.Stats()
+				mysqlStats.WithLabelValues(name, "max_open_conn").Set(float64(stats.MaxOpenConnections))
+				mysqlStats.WithLabelValues(name, "idle").Set(float64(stats.Idle))
+				mysqlStats.WithLabelValues(name, "in_use").Set(float64(stats.InUse))
+			}
+
+			for _, pool := range c.replicaPools {
+				pool.collectStats()
+			}
+			c.mu.RUnlock()
+
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// GetCtxDb returns the *gorm.DB for name bound to ctx.
+func (c *Client) GetCtxDb(ctx context.Context, name string) *gorm.DB {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	gdb, ok := c.gdbs[name]
+	if !ok {
+		c.logger.Errorc(ctx, "unknown db %s", name)
+		return nil
+	}
+
+	return gdb.WithContext(ctx)
+}
+
+// GetCtxWriteDb is GetCtxDb with the role pinned to the primary, for
+// statements that must bypass replica routing despite looking like a read
+// (e.g. "SELECT ... FOR UPDATE").
+func (c *Client) GetCtxWriteDb(ctx context.Context, name string) *gorm.DB {
+	return c.GetCtxDb(withRole(ctx, roleWrite), name)
+}
+
+// GetCtxReadDb is GetCtxDb with the role pinned to a replica, for reads
+// that are fine with replication lag and should always prefer a replica
+// when one is healthy.
+func (c *Client) GetCtxReadDb(ctx context.Context, name string) *gorm.DB {
+	return c.GetCtxDb(withRole(ctx, roleRead), name)
+}
+
+func (c *Client) Close() {
+	close(c.closeCh)
+
+	for _, pool := range c.replicaPools {
+		pool.close()
+	}
+
+	for name, sqlDb := range c.sqlDbs {
+		if err := sqlDb.Close(); err != nil {
+			c.logger.Errorf("close %s : %s", name, err.Error())
+		}
+	}
+}
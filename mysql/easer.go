@@ -0,0 +1,19 @@
+package mysql
+
+import (
+	"golang.org/x/sync/singleflight"
+)
+
+// Easer collapses N concurrent identical reads (same rendered SQL + args)
+// into a single DB round trip, handing every waiter the same result.
+type Easer struct {
+	group singleflight.Group
+}
+
+func NewEaser() *Easer {
+	return &Easer{}
+}
+
+func (e *Easer) Do(key string, fn func() (interface{}, error)) (interface{}, error, bool) {
+	return e.group.Do(key, fn)
+}
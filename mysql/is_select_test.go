@@ -0,0 +1,31 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSelect(t *testing.T) {
+	reads := []string{
+		"select * from test",
+		"  SELECT * from test  ",
+		"with cte as (select 1) select * from cte",
+		"-- trace: abc\nselect * from test",
+		"/* hint */ select * from test",
+	}
+
+	for _, query := range reads {
+		assert.True(t, isSelect(query), query)
+	}
+
+	writes := []string{
+		"insert into test values (1)",
+		"update test set title = 'x'",
+		"delete from test where id = 1",
+	}
+
+	for _, query := range writes {
+		assert.False(t, isSelect(query), query)
+	}
+}
@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRUCacher is an in-memory Cacher with a bounded size, meant for tests and
+// single-instance deployments where a shared redis isn't worth the
+// operational cost.
+type LRUCacher struct {
+	mu sync.Mutex
+
+	capacity int
+
+	ll *list.List
+
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key string
+
+	val []byte
+
+	expiresAt time.Time
+}
+
+func NewLRUCacher(capacity int) *LRUCacher {
+	return &LRUCacher{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCacher) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false, nil
+	}
+
+	c.ll.MoveToFront(elem)
+
+	return entry.val, true, nil
+}
+
+func (c *LRUCacher) Store(_ context.Context, key string, val []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		elem.Value.(*lruEntry).val = val
+		elem.Value.(*lruEntry).expiresAt = expiresAt
+		return nil
+	}
+
+	elem := c.ll.PushFront(&lruEntry{key: key, val: val, expiresAt: expiresAt})
+	c.items[key] = elem
+
+	if c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+
+	return nil
+}
+
+func (c *LRUCacher) Invalidate(_ context.Context, tables ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.items {
+		for _, table := range tables {
+			if strings.Contains(key, ":"+table+":") {
+				c.removeElement(elem)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *LRUCacher) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
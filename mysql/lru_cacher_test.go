@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacher_GetStoreInvalidate(t *testing.T) {
+	cacher := NewLRUCacher(2)
+	ctx := context.Background()
+
+	key := cacheKey("test_1", "test", "select * from test", nil)
+	assert.Nil(t, cacher.Store(ctx, key, []byte("cached"), time.Minute))
+
+	val, found, err := cacher.Get(ctx, key)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("cached"), val)
+
+	assert.Nil(t, cacher.Invalidate(ctx, "test"))
+
+	_, found, err = cacher.Get(ctx, key)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestLRUCacher_Eviction(t *testing.T) {
+	cacher := NewLRUCacher(1)
+	ctx := context.Background()
+
+	assert.Nil(t, cacher.Store(ctx, "a", []byte("1"), 0))
+	assert.Nil(t, cacher.Store(ctx, "b", []byte("2"), 0))
+
+	_, found, _ := cacher.Get(ctx, "a")
+	assert.False(t, found)
+
+	val, found, _ := cacher.Get(ctx, "b")
+	assert.True(t, found)
+	assert.Equal(t, []byte("2"), val)
+}
+
+func TestLRUCacher_Expiry(t *testing.T) {
+	cacher := NewLRUCacher(10)
+	ctx := context.Background()
+
+	assert.Nil(t, cacher.Store(ctx, "a", []byte("1"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	_, found, _ := cacher.Get(ctx, "a")
+	assert.False(t, found)
+}
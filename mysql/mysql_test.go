@@ -2,36 +2,20 @@ package mysql
 
 import (
 	"context"
-	"database/sql"
-	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/jukylin/esim/config"
 	"github.com/jukylin/esim/log"
-	"github.com/ory/dockertest/v3"
-	dc "github.com/ory/dockertest/v3/docker"
+	"github.com/jukylin/esim/tool/testutil/containers"
 	"github.com/prometheus/client_golang/prometheus"
 	io_prometheus_client "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
-var (
-	test1Config = DbConfig{
-		Db:      "test_1",
-		Dsn:     "root:123456@tcp(localhost:3306)/test_1?charset=utf8&parseTime=True&loc=Local",
-		MaxIdle: 10,
-		MaxOpen: 100}
-
-	test2Config = DbConfig{
-		Db:      "test_2",
-		Dsn:     "root:123456@tcp(localhost:3306)/test_1?charset=utf8&parseTime=True&loc=Local",
-		MaxIdle: 10,
-		MaxOpen: 100}
-)
-
 type TestStruct struct {
 	ID    int    `json:"id"`
 	Title string `json:"title"`
@@ -42,89 +26,49 @@ type UserStruct struct {
 	Username string `json:"username"`
 }
 
-var db *sql.DB
-var logger log.Logger
-
-func TestMain(m *testing.M) {
-	logger = log.NewLogger(
-		log.WithDebug(true),
-	)
-
-	pool, err := dockertest.NewPool("")
-	if err != nil {
-		logger.Fatalf("Could not connect to docker: %s", err)
-	}
-
-	opt := &dockertest.RunOptions{
-		Repository: "mysql",
-		Tag:        "latest",
-		Env:        []string{"MYSQL_ROOT_PASSWORD=123456"},
-	}
-
-	// pulls an image, creates a container based on it and runs it
-	resource, err := pool.RunWithOptions(opt, func(hostConfig *dc.HostConfig) {
-		hostConfig.PortBindings = map[dc.Port][]dc.PortBinding{
-			"3306/tcp": {{HostIP: "", HostPort: "3306"}},
-		}
-	})
-	if err != nil {
-		logger.Fatalf("Could not start resource: %s", err.Error())
-	}
-
-	err = resource.Expire(50)
-	if err != nil {
-		logger.Fatalf(err.Error())
-	}
-
-	if err := pool.Retry(func() error {
-		var err error
-		db, err = sql.Open("mysql",
-			"root:123456@tcp(localhost:3306)/mysql?charset=utf8&parseTime=True&loc=Local")
-		if err != nil {
-			return err
-		}
-		db.SetMaxOpenConns(100)
-
-		return db.Ping()
-	}); err != nil {
-		logger.Fatalf("Could not connect to docker: %s", err)
-	}
+var logger = log.NewLogger(
+	log.WithDebug(true),
+)
 
-	sqls := []string{
-		`create database test_1;`,
+// mysqlDbConfigs starts (or reuses) the shared MySQL container and returns
+// DbConfigs for the two schemas the rest of this file exercises.
+func mysqlDbConfigs(t testing.TB) (DbConfig, DbConfig) {
+	dsn := containers.StartMySQL(t,
+		`create database if not exists test_1;`,
 		`CREATE TABLE IF NOT EXISTS test_1.test(
 		  id int not NULL auto_increment,
 		  title VARCHAR(10) not NULL DEFAULT '',
 		  PRIMARY KEY (id)
 		)engine=innodb;`,
-		`create database test_2;`,
+		`create database if not exists test_2;`,
 		`CREATE TABLE IF NOT EXISTS test_2.user(
 		  id int not NULL auto_increment,
 		  username VARCHAR(10) not NULL DEFAULT '',
 			PRIMARY KEY (id)
-		)engine=innodb;`}
+		)engine=innodb;`,
+	)
 
-	for _, execSQL := range sqls {
-		res, err := db.Exec(execSQL)
-		if err != nil {
-			logger.Errorf(err.Error())
-		}
-		_, err = res.RowsAffected()
-		if err != nil {
-			logger.Errorf(err.Error())
-		}
+	schemaDsn := strings.Replace(dsn, "/mysql?", "/test_1?", 1)
+
+	test1Config := DbConfig{
+		Db:      "test_1",
+		Dsn:     schemaDsn,
+		MaxIdle: 10,
+		MaxOpen: 100,
 	}
-	code := m.Run()
 
-	db.Close()
-	// You can't defer this because os.Exit doesn't care for defer
-	if err := pool.Purge(resource); err != nil {
-		logger.Fatalf("Could not purge resource: %s", err)
+	test2Config := DbConfig{
+		Db:      "test_2",
+		Dsn:     schemaDsn,
+		MaxIdle: 10,
+		MaxOpen: 100,
 	}
-	os.Exit(code)
+
+	return test1Config, test2Config
 }
 
 func TestInitAndSingleInstance(t *testing.T) {
+	test1Config, _ := mysqlDbConfigs(t)
 	clientOptions := ClientOptions{}
 
 	client := NewClient(
@@ -147,6 +91,7 @@ func TestInitAndSingleInstance(t *testing.T) {
 }
 
 func TestProxyPatternWithTwoInstance(t *testing.T) {
+	test1Config, test2Config := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 
 	clientOptions := ClientOptions{}
@@ -188,6 +133,7 @@ func TestProxyPatternWithTwoInstance(t *testing.T) {
 }
 
 func TestMulProxyPatternWithOneInstance(t *testing.T) {
+	test1Config, _ := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 
 	clientOptions := ClientOptions{}
@@ -243,6 +189,7 @@ func TestMulProxyPatternWithOneInstance(t *testing.T) {
 }
 
 func TestMulProxyPatternWithTwoInstance(t *testing.T) {
+	test1Config, test2Config := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 
 	clientOptions := ClientOptions{}
@@ -293,6 +240,7 @@ func TestMulProxyPatternWithTwoInstance(t *testing.T) {
 }
 
 func BenchmarkParallelGetDB(b *testing.B) {
+	test1Config, test2Config := mysqlDbConfigs(b)
 	clientOnce = sync.Once{}
 
 	b.ReportAllocs()
@@ -333,6 +281,7 @@ func BenchmarkParallelGetDB(b *testing.B) {
 }
 
 func TestDummyProxy_Exec(t *testing.T) {
+	test1Config, _ := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 
 	clientOptions := ClientOptions{}
@@ -363,6 +312,7 @@ func TestDummyProxy_Exec(t *testing.T) {
 }
 
 func TestClient_GetStats(t *testing.T) {
+	test1Config, test2Config := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 	clientOptions := ClientOptions{}
 
@@ -408,6 +358,7 @@ func TestClient_GetStats(t *testing.T) {
 
 //nolint:dupl
 func TestClient_TxCommit(t *testing.T) {
+	test1Config, test2Config := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 	clientOptions := ClientOptions{}
 	client := NewClient(
@@ -445,6 +396,7 @@ func TestClient_TxCommit(t *testing.T) {
 
 //nolint:dupl
 func TestClient_TxRollBack(t *testing.T) {
+	test1Config, test2Config := mysqlDbConfigs(t)
 	clientOnce = sync.Once{}
 	clientOptions := ClientOptions{}
 	client := NewClient(
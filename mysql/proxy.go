@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jukylin/esim/config"
+	"github.com/jukylin/esim/log"
+	"gorm.io/gorm"
+)
+
+// Proxy sits in front of gorm's ConnPool so cross-cutting concerns
+// (monitoring, caching, ...) can observe or rewrite every query without
+// gorm itself knowing about them. Implementations chain via NextProxy,
+// delegating to the next proxy (or the real *sql.DB) once they're done.
+type Proxy interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+
+	NextProxy(next interface{})
+}
+
+// beginTx forwards BeginTx down the chain to whatever next actually is:
+// another Proxy (gorm.ConnPoolBeginner) or the real *sql.DB
+// (gorm.TxBeginner). Every Proxy implementation needs this, otherwise
+// gorm.DB.Begin sees a ConnPool that satisfies neither interface and
+// fails with ErrInvalidTransaction before the call ever reaches the
+// driver.
+func beginTx(next gormConnPoolLike, ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	switch beginner := interface{}(next).(type) {
+	case gorm.ConnPoolBeginner:
+		return beginner.BeginTx(ctx, opts)
+	case gorm.TxBeginner:
+		return beginner.BeginTx(ctx, opts)
+	default:
+		return nil, errors.New("mysql: next proxy does not support transactions")
+	}
+}
+
+// MonitorProxy logs the error of every Exec or Query that fails as it
+// passes through it. It records no timings and no metrics.
+type MonitorProxy struct {
+	conf config.Config
+
+	logger log.Logger
+
+	next gormConnPoolLike
+}
+
+// gormConnPoolLike is the query half of gorm.ConnPool that a proxy
+// forwards to; it's satisfied by *sql.DB and by any other Proxy in the chain.
+type gormConnPoolLike interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type MonitorProxyOptions struct{}
+
+type MonitorProxyOption func(p *MonitorProxy)
+
+func (MonitorProxyOptions) WithConf(conf config.Config) MonitorProxyOption {
+	return func(p *MonitorProxy) {
+		p.conf = conf
+	}
+}
+
+func (MonitorProxyOptions) WithLogger(logger log.Logger) MonitorProxyOption {
+	return func(p *MonitorProxy) {
+		p.logger = logger
+	}
+}
+
+func NewMonitorProxy(options ...MonitorProxyOption) *MonitorProxy {
+	p := &MonitorProxy{}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+func (p *MonitorProxy) NextProxy(next interface{}) {
+	if connPool, ok := next.(gormConnPoolLike); ok {
+		p.next = connPool
+	}
+}
+
+func (p *MonitorProxy) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.next.PrepareContext(ctx, query)
+}
+
+func (p *MonitorProxy) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := p.next.ExecContext(ctx, query, args...)
+	if err != nil {
+		p.logger.Errorc(ctx, "exec %s : %s", query, err.Error())
+	}
+
+	return result, err
+}
+
+func (p *MonitorProxy) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := p.next.QueryContext(ctx, query, args...)
+	if err != nil {
+		p.logger.Errorc(ctx, "query %s : %s", query, err.Error())
+	}
+
+	return rows, err
+}
+
+func (p *MonitorProxy) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.next.QueryRowContext(ctx, query, args...)
+}
+
+func (p *MonitorProxy) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return beginTx(p.next, ctx, opts)
+}
@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/jukylin/esim/redis"
+)
+
+// RedisCacher is the production Cacher backend: cached query results live
+// in redis with a per-entry TTL, and invalidation deletes every cached
+// query key tracked in an affected table's redis set, then the set itself.
+type RedisCacher struct {
+	client *redis.RedisClient
+}
+
+func NewRedisCacher(client *redis.RedisClient) *RedisCacher {
+	return &RedisCacher{client: client}
+}
+
+func (c *RedisCacher) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	val, err := c.client.GetCtxRedisConn(ctx).Do("GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if val == nil {
+		return nil, false, nil
+	}
+
+	b, ok := val.([]byte)
+	if !ok {
+		return nil, false, nil
+	}
+
+	return b, true, nil
+}
+
+func (c *RedisCacher) Store(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	conn := c.client.GetCtxRedisConn(ctx)
+
+	if _, err := conn.Do("SET", key, val, "PX", ttl.Milliseconds()); err != nil {
+		return err
+	}
+
+	table := tableFromCacheKey(key)
+	if table == "" {
+		return nil
+	}
+
+	setKey := "cache:keys:" + table
+
+	if _, err := conn.Do("SADD", setKey, key); err != nil {
+		return err
+	}
+
+	// Keep the tracking set from outliving the keys it tracks.
+	if _, err := conn.Do("PEXPIRE", setKey, ttl.Milliseconds()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Invalidate removes every cached key recorded for the given tables. Keys
+// are tracked per table in a redis set (named "cache:keys:<table>") that
+// Store adds to on every call so Invalidate doesn't need to scan.
+func (c *RedisCacher) Invalidate(ctx context.Context, tables ...string) error {
+	conn := c.client.GetCtxRedisConn(ctx)
+
+	for _, table := range tables {
+		setKey := "cache:keys:" + table
+
+		keys, err := conn.Do("SMEMBERS", setKey)
+		if err != nil {
+			return err
+		}
+
+		members, ok := keys.([]interface{})
+		if !ok || len(members) == 0 {
+			continue
+		}
+
+		args := append([]interface{}{}, members...)
+		if _, err := conn.Do("DEL", args...); err != nil {
+			return err
+		}
+
+		if _, err := conn.Do("DEL", setKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
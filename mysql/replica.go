@@ -0,0 +1,188 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/jukylin/esim/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// replicaPolicy picks one healthy replica out of a pool for a read.
+type replicaPolicy string
+
+const (
+	PolicyRoundRobin replicaPolicy = "round_robin"
+	PolicyRandom     replicaPolicy = "random"
+	PolicyLeastConn  replicaPolicy = "least_conn"
+)
+
+const (
+	replicaFailThreshold = 3
+
+	replicaCoolDown = 30 * time.Second
+)
+
+var mysqlReplicaStats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "esim",
+	Subsystem: "mysql",
+	Name:      "replica_stats",
+	Help:      "per-replica in-use connections (database/sql.DBStats.InUse), labelled by role and instance",
+}, []string{"db", "role", "instance"})
+
+func init() {
+	prometheus.MustRegister(mysqlReplicaStats)
+}
+
+// replica wraps one replica *sql.DB with the bookkeeping the health checker
+// and the routing policy need: whether it's currently eligible for reads,
+// and how many consecutive ping failures it has accrued.
+type replica struct {
+	instance string
+
+	sqlDb *sql.DB
+
+	connPool gorm.ConnPool
+
+	healthy int32 // atomic bool, 1 == eligible for reads
+
+	failCount int32
+
+	ejectedAt atomic.Value // time.Time
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+func (r *replica) inUse() int {
+	return r.sqlDb.Stats().InUse
+}
+
+// replicaPool routes reads across a primary's replica set according to a
+// configurable policy, ejecting replicas that fail repeated health checks
+// and re-admitting them after a cool-down.
+type replicaPool struct {
+	db string
+
+	policy replicaPolicy
+
+	replicas []*replica
+
+	rrCounter uint64
+
+	logger log.Logger
+
+	stopCh chan struct{}
+}
+
+func newReplicaPool(db string, policy replicaPolicy, replicas []*replica, logger log.Logger) *replicaPool {
+	if policy == "" {
+		policy = PolicyRoundRobin
+	}
+
+	return &replicaPool{
+		db:       db,
+		policy:   policy,
+		replicas: replicas,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// pick returns a healthy replica's ConnPool per the configured policy, or
+// nil if every replica is currently ejected.
+func (p *replicaPool) pick(ctx context.Context) gorm.ConnPool {
+	healthy := make([]*replica, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		if r.isHealthy() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	var chosen *replica
+
+	switch p.policy {
+	case PolicyRandom:
+		chosen = healthy[rand.Intn(len(healthy))]
+
+	case PolicyLeastConn:
+		chosen = healthy[0]
+		for _, r := range healthy[1:] {
+			if r.inUse() < chosen.inUse() {
+				chosen = r
+			}
+		}
+
+	default: // PolicyRoundRobin
+		idx := atomic.AddUint64(&p.rrCounter, 1)
+		chosen = healthy[idx%uint64(len(healthy))]
+	}
+
+	return chosen.connPool
+}
+
+// healthCheck pings every replica on an interval, ejecting one after
+// replicaFailThreshold consecutive failures and re-admitting it once a
+// ping succeeds again after replicaCoolDown has elapsed since ejection.
+func (p *replicaPool) healthCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, r := range p.replicas {
+				p.checkOne(r)
+			}
+
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+func (p *replicaPool) checkOne(r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := r.sqlDb.PingContext(ctx)
+	if err != nil {
+		if atomic.AddInt32(&r.failCount, 1) >= replicaFailThreshold && r.isHealthy() {
+			atomic.StoreInt32(&r.healthy, 0)
+			r.ejectedAt.Store(time.Now())
+			p.logger.Errorf("ejected replica %s/%s after repeated ping failures: %s", p.db, r.instance, err.Error())
+		}
+
+		return
+	}
+
+	atomic.StoreInt32(&r.failCount, 0)
+
+	if !r.isHealthy() {
+		ejectedAt, _ := r.ejectedAt.Load().(time.Time)
+		if time.Since(ejectedAt) >= replicaCoolDown {
+			atomic.StoreInt32(&r.healthy, 1)
+			p.logger.Infof("re-admitted replica %s/%s", p.db, r.instance)
+		}
+	}
+}
+
+func (p *replicaPool) close() {
+	close(p.stopCh)
+}
+
+func (p *replicaPool) collectStats() {
+	for _, r := range p.replicas {
+		stats := r.sqlDb.Stats()
+		mysqlReplicaStats.WithLabelValues(p.db, "replica", r.instance).Set(float64(stats.InUse))
+	}
+}
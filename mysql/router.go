@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// dbRole forces a statement onto a specific physical db, bypassing the
+// SELECT/write sniffing replicaRouter otherwise does. Set via
+// GetCtxReadDb/GetCtxWriteDb, useful for e.g. "SELECT ... FOR UPDATE"
+// which must land on the primary despite being a read.
+type dbRole string
+
+const (
+	roleWrite dbRole = "write"
+
+	roleRead dbRole = "read"
+)
+
+type roleCtxKey struct{}
+
+func withRole(ctx context.Context, role dbRole) context.Context {
+	return context.WithValue(ctx, roleCtxKey{}, role)
+}
+
+func roleFromCtx(ctx context.Context) (dbRole, bool) {
+	role, ok := ctx.Value(roleCtxKey{}).(dbRole)
+	return role, ok
+}
+
+// replicaRouter is the gorm.ConnPool installed in place of the raw
+// *sql.DB: it sends writes (and anything pinned to roleWrite via withRole)
+// straight to the primary, and sends SELECTs (and queries pinned to
+// roleRead) to a replica chosen by pool's policy,
+// falling back to the primary when every replica is ejected or none are
+// configured.
+type replicaRouter struct {
+	write gorm.ConnPool
+
+	pool *replicaPool
+}
+
+func (r *replicaRouter) readPool(ctx context.Context, query string) gorm.ConnPool {
+	if role, ok := roleFromCtx(ctx); ok {
+		if role == roleWrite {
+			return r.write
+		}
+	} else if !isSelect(query) {
+		return r.write
+	}
+
+	if r.pool != nil {
+		if replica := r.pool.pick(ctx); replica != nil {
+			return replica
+		}
+	}
+
+	return r.write
+}
+
+func (r *replicaRouter) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.write.PrepareContext(ctx, query)
+}
+
+func (r *replicaRouter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.write.ExecContext(ctx, query, args...)
+}
+
+func (r *replicaRouter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.readPool(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (r *replicaRouter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.readPool(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx always starts the transaction on the primary; every statement
+// gorm issues against the returned *sql.Tx then naturally sticks to it.
+func (r *replicaRouter) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	switch beginner := r.write.(type) {
+	case gorm.ConnPoolBeginner:
+		return beginner.BeginTx(ctx, opts)
+	case gorm.TxBeginner:
+		return beginner.BeginTx(ctx, opts)
+	default:
+		return nil, errors.New("mysql: write pool does not support transactions")
+	}
+}
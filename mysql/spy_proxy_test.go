@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jukylin/esim/log"
+	"gorm.io/gorm"
+)
+
+// spyProxy is a test double that records whether each Proxy method was
+// called, then forwards to whatever NextProxy wired in. It's named like
+// a MonitorProxy/CacheProxy for a reason: it's spliced into the same
+// WithProxy chain in the tests in mysql_test.go to assert the chain is actually
+// exercised end to end, not just built.
+type spyProxy struct {
+	name string
+
+	logger log.Logger
+
+	next gormConnPoolLike
+
+	QueryWasCalled bool
+
+	QueryRowWasCalled bool
+
+	ExecWasCalled bool
+
+	PrepareWasCalled bool
+}
+
+func newSpyProxy(logger log.Logger, name string) *spyProxy {
+	return &spyProxy{
+		name:   name,
+		logger: logger,
+	}
+}
+
+func (p *spyProxy) NextProxy(next interface{}) {
+	if connPool, ok := next.(gormConnPoolLike); ok {
+		p.next = connPool
+	}
+}
+
+func (p *spyProxy) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	p.PrepareWasCalled = true
+	return p.next.PrepareContext(ctx, query)
+}
+
+func (p *spyProxy) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.ExecWasCalled = true
+	return p.next.ExecContext(ctx, query, args...)
+}
+
+func (p *spyProxy) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.QueryWasCalled = true
+	return p.next.QueryContext(ctx, query, args...)
+}
+
+func (p *spyProxy) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	p.QueryRowWasCalled = true
+	return p.next.QueryRowContext(ctx, query, args...)
+}
+
+func (p *spyProxy) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return beginTx(p.next, ctx, opts)
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jukylin/esim/config"
+	"github.com/jukylin/esim/log"
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// DbConfig describes a single logical Postgres database: how to dial it
+// and the pool limits to apply once connected.
+type DbConfig struct {
+	Db string
+
+	Dsn string
+
+	MaxIdle int
+
+	MaxOpen int
+}
+
+// Proxy sits in front of gorm's ConnPool so cross-cutting concerns
+// (monitoring, caching, ...) can observe or rewrite every query without
+// gorm itself knowing about them. It mirrors mysql.Proxy; implementations
+// chain via NextProxy, delegating to the next proxy (or the real *sql.DB)
+// once they're done.
+type Proxy interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+
+	NextProxy(next interface{})
+}
+
+// gormConnPoolLike is the query half of gorm.ConnPool that a Proxy
+// forwards to; it's satisfied by *sql.DB and by any other Proxy in the
+// chain.
+type gormConnPoolLike interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// beginTx forwards BeginTx down the chain to whatever next actually is:
+// another Proxy (gorm.ConnPoolBeginner) or the real *sql.DB
+// (gorm.TxBeginner). Every Proxy implementation needs this, otherwise
+// gorm.DB.Begin sees a ConnPool that satisfies neither interface and
+// fails with ErrInvalidTransaction before the call ever reaches the
+// driver.
+func beginTx(next gormConnPoolLike, ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	switch beginner := interface{}(next).(type) {
+	case gorm.ConnPoolBeginner:
+		return beginner.BeginTx(ctx, opts)
+	case gorm.TxBeginner:
+		return beginner.BeginTx(ctx, opts)
+	default:
+		return nil, errors.New("postgres: next proxy does not support transactions")
+	}
+}
+
+// Client owns one *gorm.DB per configured DbConfig, built once and shared
+// for the lifetime of the process. It mirrors mysql.Client so callers
+// already familiar with that package feel at home here.
+type Client struct {
+	mu sync.RWMutex
+
+	dbConfigs []DbConfig
+
+	conf config.Config
+
+	gormConfig *gorm.Config
+
+	proxyFuncs []func() interface{}
+
+	stateTicker time.Duration
+
+	logger log.Logger
+
+	sqlDbs map[string]*sql.DB
+
+	gdbs map[string]*gorm.DB
+
+	closeCh chan struct{}
+}
+
+var clientOnce sync.Once
+
+var singleClient *Client
+
+var postgresStats = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "esim",
+	Subsystem: "postgres",
+	Name:      "stats",
+	Help:      "database/sql.DBStats collected per configured db",
+}, []string{"db", "stats"})
+
+func init() {
+	prometheus.MustRegister(postgresStats)
+}
+
+type ClientOptions struct{}
+
+type ClientOption func(c *Client)
+
+func (ClientOptions) WithDbConfig(dbConfigs []DbConfig) ClientOption {
+	return func(c *Client) {
+		c.dbConfigs = dbConfigs
+	}
+}
+
+func (ClientOptions) WithConf(conf config.Config) ClientOption {
+	return func(c *Client) {
+		c.conf = conf
+	}
+}
+
+func (ClientOptions) WithGormConfig(gormConfig *gorm.Config) ClientOption {
+	return func(c *Client) {
+		c.gormConfig = gormConfig
+	}
+}
+
+// WithProxy registers a chain of proxy factories. Each factory is invoked
+// once per physical connection so proxies stay per-connection-pool, and
+// the proxies are chained together via NextProxy in the order supplied
+// here, with the last one wrapping the real *sql.DB.
+func (ClientOptions) WithProxy(proxyFuncs ...func() interface{}) ClientOption {
+	return func(c *Client) {
+		c.proxyFuncs = proxyFuncs
+	}
+}
+
+func (ClientOptions) WithStateTicker(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.stateTicker = d
+	}
+}
+
+// NewClient builds the singleton Client on first call; later calls just
+// return the existing instance, options included.
+func NewClient(options ...ClientOption) *Client {
+	clientOnce.Do(func() {
+		singleClient = &Client{
+			logger:      log.NewLogger(),
+			sqlDbs:      make(map[string]*sql.DB),
+			gdbs:        make(map[string]*gorm.DB),
+			closeCh:     make(chan struct{}),
+			stateTicker: 10 * time.Second,
+		}
+
+		for _, option := range options {
+			option(singleClient)
+		}
+
+		singleClient.init()
+	})
+
+	return singleClient
+}
+
+func (c *Client) init() {
+	for _, dbConfig := range c.dbConfigs {
+		sqlDb, err := sql.Open("postgres", dbConfig.Dsn)
+		if err != nil {
+			c.logger.Fatalf("open %s : %s", dbConfig.Db, err.Error())
+		}
+
+		sqlDb.SetMaxIdleConns(dbConfig.MaxIdle)
+		sqlDb.SetMaxOpenConns(dbConfig.MaxOpen)
+
+		c.sqlDbs[dbConfig.Db] = sqlDb
+
+		gormConfig := c.gormConfig
+		if gormConfig == nil {
+			gormConfig = &gorm.Config{}
+		}
+
+		gdb, err := gorm.Open(pgdriver.New(pgdriver.Config{
+			Conn: c.wrapConnPool(sqlDb),
+		}), gormConfig)
+		if err != nil {
+			c.logger.Fatalf("gorm.Open %s : %s", dbConfig.Db, err.Error())
+		}
+
+		c.gdbs[dbConfig.Db] = gdb
+	}
+
+	go c.collectStats()
+}
+
+// wrapConnPool chains the configured proxies in front of sqlDb, last
+// factory closest to the driver, first factory closest to gorm. It's
+// called once per physical connection, so every configured db gets its
+// own proxy chain.
+func (c *Client) wrapConnPool(sqlDb *sql.DB) gorm.ConnPool {
+	var connPool gorm.ConnPool = sqlDb
+
+	for i := len(c.proxyFuncs) - 1; i >= 0; i-- {
+		proxy, ok := c.proxyFuncs[i]().(Proxy)
+		if !ok {
+			continue
+		}
+
+		proxy.NextProxy(connPool)
+		connPool = proxy
+	}
+
+	return connPool
+}
+
+func (c *Client) collectStats() {
+	ticker := time.NewTicker(c.stateTicker)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.RLock()
+			for name, sqlDb := range c.sqlDbs {
+				stats := sqlDb.Stats()
+				postgresStats.WithLabelValues(name, "max_open_conn").Set(float64(stats.MaxOpenConnections))
+				postgresStats.WithLabelValues(name, "idle").Set(float64(stats.Idle))
+				postgresStats.WithLabelValues(name, "in_use").Set(float64(stats.InUse))
+			}
+			c.mu.RUnlock()
+
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// GetCtxDb returns the *gorm.DB for name bound to ctx.
+func (c *Client) GetCtxDb(ctx context.Context, name string) *gorm.DB {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	gdb, ok := c.gdbs[name]
+	if !ok {
+		c.logger.Errorc(ctx, "unknown db %s", name)
+		return nil
+	}
+
+	return gdb.WithContext(ctx)
+}
+
+func (c *Client) Close() {
+	close(c.closeCh)
+
+	for name, sqlDb := range c.sqlDbs {
+		if err := sqlDb.Close(); err != nil {
+			c.logger.Errorf("close %s : %s", name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jukylin/esim/log"
+	"github.com/jukylin/esim/tool/testutil/containers"
+	"github.com/prometheus/client_golang/prometheus"
+	io_prometheus_client "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type TestStruct struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+var logger = log.NewLogger(
+	log.WithDebug(true),
+)
+
+func postgresDbConfig(t *testing.T) DbConfig {
+	dsn := containers.StartPostgres(t,
+		`CREATE TABLE IF NOT EXISTS test(
+		  id SERIAL PRIMARY KEY,
+		  title VARCHAR(10) NOT NULL DEFAULT ''
+		);`,
+	)
+
+	return DbConfig{
+		Db:      "test",
+		Dsn:     dsn,
+		MaxIdle: 10,
+		MaxOpen: 100,
+	}
+}
+
+func TestClient_GetCtxDb(t *testing.T) {
+	clientOnce = sync.Once{}
+
+	testConfig := postgresDbConfig(t)
+	clientOptions := ClientOptions{}
+
+	client := NewClient(
+		clientOptions.WithDbConfig([]DbConfig{testConfig}),
+		clientOptions.WithGormConfig(&gorm.Config{
+			Logger: logger.(*log.Elogger).Glog(),
+		}),
+	)
+
+	ctx := context.Background()
+	db := client.GetCtxDb(ctx, "test")
+	assert.NotNil(t, db)
+
+	_, ok := client.gdbs["test"]
+	assert.True(t, ok)
+
+	client.Close()
+}
+
+func TestClient_TxCommit(t *testing.T) {
+	clientOnce = sync.Once{}
+
+	testConfig := postgresDbConfig(t)
+	clientOptions := ClientOptions{}
+
+	client := NewClient(
+		clientOptions.WithDbConfig([]DbConfig{testConfig}),
+		clientOptions.WithGormConfig(&gorm.Config{
+			Logger: logger.(*log.Elogger).Glog(),
+		}),
+	)
+
+	ctx := context.Background()
+	db := client.GetCtxDb(ctx, "test")
+
+	tx := db.Begin()
+	assert.Nil(t, tx.Error)
+	tx.Exec("insert into test (title) values ('test')")
+	tx.Commit()
+	assert.Nil(t, tx.Error)
+
+	row := &TestStruct{}
+	db.Table("test").First(row)
+	assert.Equal(t, "test", row.Title)
+
+	client.Close()
+}
+
+func TestClient_WithProxy(t *testing.T) {
+	clientOnce = sync.Once{}
+
+	testConfig := postgresDbConfig(t)
+	clientOptions := ClientOptions{}
+
+	spy1 := newSpyProxy(log.NewLogger(), "spyProxy1")
+	spy2 := newSpyProxy(log.NewLogger(), "spyProxy2")
+
+	client := NewClient(
+		clientOptions.WithDbConfig([]DbConfig{testConfig}),
+		clientOptions.WithGormConfig(&gorm.Config{
+			Logger: logger.(*log.Elogger).Glog(),
+		}),
+		clientOptions.WithProxy(
+			func() interface{} { return spy1 },
+			func() interface{} { return spy2 },
+		),
+	)
+
+	ctx := context.Background()
+	db := client.GetCtxDb(ctx, "test")
+
+	row := &TestStruct{}
+	db.Table("test").First(row)
+
+	assert.True(t, spy1.QueryWasCalled)
+	assert.True(t, spy2.QueryWasCalled)
+
+	tx := db.Begin()
+	assert.Nil(t, tx.Error)
+	tx.Exec("insert into test (title) values ('proxied')")
+	tx.Commit()
+	assert.Nil(t, tx.Error)
+
+	found := &TestStruct{}
+	db.Table("test").Where("title = ?", "proxied").First(found)
+	assert.Equal(t, "proxied", found.Title)
+
+	client.Close()
+}
+
+func TestClient_GetStats(t *testing.T) {
+	clientOnce = sync.Once{}
+
+	testConfig := postgresDbConfig(t)
+	clientOptions := ClientOptions{}
+
+	client := NewClient(
+		clientOptions.WithDbConfig([]DbConfig{testConfig}),
+		clientOptions.WithStateTicker(10*time.Millisecond),
+		clientOptions.WithGormConfig(&gorm.Config{
+			Logger: logger.(*log.Elogger).Glog(),
+		}),
+	)
+
+	ctx := context.Background()
+	db := client.GetCtxDb(ctx, "test")
+	assert.NotNil(t, db)
+
+	time.Sleep(100 * time.Millisecond)
+
+	lab := prometheus.Labels{"db": "test", "stats": "max_open_conn"}
+	c, _ := postgresStats.GetMetricWith(lab)
+	metric := &io_prometheus_client.Metric{}
+	err := c.Write(metric)
+	assert.Nil(t, err)
+
+	assert.Equal(t, float64(100), metric.Gauge.GetValue())
+
+	client.Close()
+}
+
+func TestClient_TxRollback(t *testing.T) {
+	clientOnce = sync.Once{}
+
+	testConfig := postgresDbConfig(t)
+	clientOptions := ClientOptions{}
+
+	client := NewClient(
+		clientOptions.WithDbConfig([]DbConfig{testConfig}),
+		clientOptions.WithGormConfig(&gorm.Config{
+			Logger: logger.(*log.Elogger).Glog(),
+		}),
+	)
+
+	ctx := context.Background()
+	db := client.GetCtxDb(ctx, "test")
+
+	tx := db.Begin()
+	assert.Nil(t, tx.Error)
+	tx.Exec("insert into test (id, title) values (100, 'rollback')")
+	tx.Rollback()
+	assert.Nil(t, tx.Error)
+
+	row := TestStruct{}
+	db.Table("test").Where("id = 100").First(&row)
+	assert.Equal(t, 0, row.ID)
+
+	client.Close()
+}
@@ -13,6 +13,10 @@ type TestStruct struct{
 
 }
 
+type Client[V any] struct {
+	val V
+}
+
 
 type Close interface {
 	Close(string, int) error
@@ -46,3 +50,11 @@ type Test interface {
 	Iface10(Close)
 }
 
+// Repo is generic, with a nested map[string]*Client[T] in the style of
+// Iface4's map[string]*aa.RedisClient - the -type-params flag forwards T
+// through to a generated constructor; without it, generating a decorator
+// for Repo fails.
+type Repo[T any] interface {
+	GetMap(map[string]*Client[T]) map[string]T
+}
+
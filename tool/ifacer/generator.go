@@ -0,0 +1,179 @@
+package ifacer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// decoratorTemplate emits one pass-through decorator per interface: every
+// method (including ones pulled in by --flatten-embeds) forwards straight
+// to Next, which is where a caller plugs in monitoring, a test spy, or
+// whatever else needs to sit between an interface's consumers and its
+// real implementation. Without --flatten-embeds, an embed is instead
+// anonymously embedded (unlike Next, a named field) so Go's method promotion - not this
+// template - satisfies the embedded interface's methods.
+var decoratorTemplate = template.Must(template.New("decorator").Parse(`
+type {{.StructName}}{{.TypeParamDecl}} struct {
+	Next {{.Name}}{{.TypeArgList}}
+{{range .Embeds}}
+	{{.Expr}}
+{{end}}
+}
+
+func New{{.Name}}Decorator{{.TypeParamDecl}}(next {{.Name}}{{.TypeArgList}}) *{{.StructName}}{{.TypeArgList}} {
+	return &{{.StructName}}{{.TypeArgList}}{
+		Next: next,
+{{range .Embeds}}
+		{{.FieldName}}: next,
+{{end}}
+	}
+}
+{{range .Methods}}
+{{if .Void}}
+func (d *{{$.StructName}}{{$.TypeArgList}}) {{.Name}}({{.Params}}) {
+	d.Next.{{.Name}}({{.Args}})
+}
+{{else}}
+func (d *{{$.StructName}}{{$.TypeArgList}}) {{.Name}}({{.Params}}) ({{.Results}}) {
+	return d.Next.{{.Name}}({{.Args}})
+}
+{{end}}
+{{end}}`))
+
+// templateEmbed is one unflattened embed, rendered both as the anonymous
+// field declaration (Expr, e.g. "io.Closer") and as the key used to set
+// it in the constructor's composite literal (FieldName, the same
+// expression with any package qualifier stripped, e.g. "Closer" - that's
+// the implicit field name Go gives an anonymous embed).
+type templateEmbed struct {
+	Expr string
+
+	FieldName string
+}
+
+type templateData struct {
+	Name string
+
+	StructName string
+
+	TypeParamDecl string
+
+	TypeArgList string
+
+	Methods []Method
+
+	Embeds []templateEmbed
+}
+
+// embedFieldName returns the implicit field name Go gives an anonymous
+// embed of expr, e.g. "io.Closer" embeds as field "Closer".
+func embedFieldName(expr string) string {
+	if i := strings.LastIndex(expr, "."); i >= 0 {
+		expr = expr[i+1:]
+	}
+
+	return expr
+}
+
+// Generator turns parsed Iface values into Go source for a decorator of
+// each one. TypeParams forwards generic constraints through to the
+// generated New<Iface>Decorator constructor; without it, Generate returns
+// an error for a generic interface instead of emitting a decorator.
+type Generator struct {
+	TypeParams bool
+}
+
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Generate renders the decorator source for every iface and gofmt's the
+// result, matching the style of the other code generators in this repo
+// (see tool/factory's template-driven New<Struct> constructors). imports
+// is the full set of imports available in the file the ifaces were
+// parsed from; only the ones actually referenced by a generated
+// signature are emitted, since an unused import doesn't compile.
+func (g *Generator) Generate(pkgName string, ifaces []*Iface, imports []Import) ([]byte, error) {
+	var body bytes.Buffer
+
+	for _, iface := range ifaces {
+		if !g.TypeParams && len(iface.TypeParams) > 0 {
+			return nil, fmt.Errorf("ifacer: %s is generic but -type-params wasn't set: the generated decorator would reference an unbound type parameter and not compile", iface.Name)
+		}
+
+		data := templateData{
+			Name:       iface.Name,
+			StructName: iface.Name + "Decorator",
+		}
+
+		if g.TypeParams && len(iface.TypeParams) > 0 {
+			decls := make([]string, 0, len(iface.TypeParams))
+			args := make([]string, 0, len(iface.TypeParams))
+
+			for _, tp := range iface.TypeParams {
+				decls = append(decls, fmt.Sprintf("%s %s", tp.Name, tp.Constraint))
+				args = append(args, tp.Name)
+			}
+
+			data.TypeParamDecl = "[" + joinComma(decls) + "]"
+			data.TypeArgList = "[" + joinComma(args) + "]"
+		}
+
+		data.Methods = iface.Methods
+
+		for _, embed := range iface.Embeds {
+			data.Embeds = append(data.Embeds, templateEmbed{Expr: embed, FieldName: embedFieldName(embed)})
+		}
+
+		if err := decoratorTemplate.Execute(&body, data); err != nil {
+			return nil, fmt.Errorf("ifacer: render %s: %w", iface.Name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	writeImports(&buf, imports, body.String())
+	buf.Write(body.Bytes())
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("ifacer: gofmt generated source: %w\n%s", err, buf.String())
+	}
+
+	return formatted, nil
+}
+
+// writeImports emits an import block with only the imports whose local
+// name is actually referenced as "Name." somewhere in body.
+func writeImports(buf *bytes.Buffer, imports []Import, body string) {
+	var used []Import
+
+	for _, imp := range imports {
+		pattern := `\b` + regexp.QuoteMeta(imp.Name) + `\.`
+		if regexp.MustCompile(pattern).MatchString(body) {
+			used = append(used, imp)
+		}
+	}
+
+	if len(used) == 0 {
+		return
+	}
+
+	buf.WriteString("import (\n")
+
+	for _, imp := range used {
+		if path.Base(imp.Path) == imp.Name {
+			fmt.Fprintf(buf, "\t%q\n", imp.Path)
+		} else {
+			fmt.Fprintf(buf, "\t%s %q\n", imp.Name, imp.Path)
+		}
+	}
+
+	buf.WriteString(")\n\n")
+}
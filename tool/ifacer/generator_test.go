@@ -0,0 +1,170 @@
+package ifacer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+// stubImporter resolves "context" for real (via the local toolchain's
+// export data) and fakes out the handful of non-stdlib packages the
+// example fixture references, each as a single opaque named type. That's
+// enough for go/types to check the generated decorators' signatures
+// without needing those packages' real source or a go.mod in this repo.
+type stubImporter struct {
+	real types.Importer
+
+	// cache ensures the two files being checked together (the fixture
+	// and its generated decorator both import e.g. the repo package)
+	// resolve to the exact same *types.Package - go/types treats two
+	// distinct Package values for the same path as unrelated types.
+	cache map[string]*types.Package
+}
+
+func newStubImporter() stubImporter {
+	return stubImporter{real: importer.Default(), cache: map[string]*types.Package{}}
+}
+
+func (s stubImporter) Import(path string) (*types.Package, error) {
+	switch path {
+	case "context", "os":
+		return s.real.Import(path)
+	}
+
+	if pkg, ok := s.cache[path]; ok {
+		return pkg, nil
+	}
+
+	var pkg *types.Package
+
+	switch path {
+	case "github.com/gomodule/redigo/redis":
+		pkg = stubPackage(path, "redis", "Pool")
+	case "github.com/jukylin/esim/redis":
+		pkg = stubPackage(path, "redis", "RedisClient")
+	case "github.com/jukylin/esim/tool/ifacer/example/repo":
+		pkg = stubPackage(path, "repo", "Repo")
+	default:
+		return nil, fmt.Errorf("stubImporter: unstubbed import %q", path)
+	}
+
+	s.cache[path] = pkg
+
+	return pkg, nil
+}
+
+func stubPackage(path, name string, typeNames ...string) *types.Package {
+	pkg := types.NewPackage(path, name)
+
+	for _, typeName := range typeNames {
+		obj := types.NewTypeName(token.NoPos, pkg, typeName, nil)
+		types.NewNamed(obj, types.NewInterfaceType(nil, nil), nil)
+		pkg.Scope().Insert(obj)
+	}
+
+	pkg.MarkComplete()
+
+	return pkg
+}
+
+// TestGenerateFlattenEmbedsAndTypeParams type-checks the generated
+// decorator together with the fixture it was generated from, so a
+// signature that merely looks right as text (see the now-fixed "_" used
+// as a call argument and missing imports) doesn't slip past review.
+func TestGenerateFlattenEmbedsAndTypeParams(t *testing.T) {
+	p := NewParser()
+	p.FlattenEmbeds = true
+
+	ifaces, err := p.Parse("example/ifacer.go", []string{"Test", "Repo"})
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	g := NewGenerator()
+	g.TypeParams = true
+
+	src, err := g.Generate("example1", ifaces, p.Imports())
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+
+	fset := token.NewFileSet()
+
+	origFile, err := parser.ParseFile(fset, "example/ifacer.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse fixture: %s", err)
+	}
+
+	genFile, err := parser.ParseFile(fset, "generated.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse generated source: %s\n%s", err, src)
+	}
+
+	conf := types.Config{Importer: newStubImporter()}
+
+	if _, err := conf.Check("example1", fset, []*ast.File{origFile, genFile}, nil); err != nil {
+		t.Fatalf("generated source does not type-check: %s\n%s", err, src)
+	}
+}
+
+// TestGenerateEmbedWithoutFlatten covers the --flatten-embeds=false path:
+// Test embeds Close, so the generated decorator must still satisfy Test
+// (via an anonymous Close field and method promotion) without the
+// generator ever enumerating Close's methods itself.
+func TestGenerateEmbedWithoutFlatten(t *testing.T) {
+	p := NewParser()
+	p.FlattenEmbeds = false
+
+	ifaces, err := p.Parse("example/ifacer.go", []string{"Test", "Close"})
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	g := NewGenerator()
+	g.TypeParams = true
+
+	src, err := g.Generate("example1", ifaces, p.Imports())
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+
+	fset := token.NewFileSet()
+
+	origFile, err := parser.ParseFile(fset, "example/ifacer.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse fixture: %s", err)
+	}
+
+	genFile, err := parser.ParseFile(fset, "generated.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse generated source: %s\n%s", err, src)
+	}
+
+	conf := types.Config{Importer: newStubImporter()}
+
+	if _, err := conf.Check("example1", fset, []*ast.File{origFile, genFile}, nil); err != nil {
+		t.Fatalf("generated source does not type-check: %s\n%s", err, src)
+	}
+}
+
+// TestGenerateGenericWithoutTypeParamsFails covers the -type-params=false
+// path for a generic interface: Generate must fail instead of silently
+// emitting a decorator that references the unbound type parameter T.
+func TestGenerateGenericWithoutTypeParamsFails(t *testing.T) {
+	p := NewParser()
+
+	ifaces, err := p.Parse("example/ifacer.go", []string{"Repo"})
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	g := NewGenerator()
+
+	if _, err := g.Generate("example1", ifaces, p.Imports()); err == nil {
+		t.Fatal("Generate: expected an error for a generic interface with TypeParams off, got nil")
+	}
+}
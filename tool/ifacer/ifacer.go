@@ -0,0 +1,92 @@
+package ifacer
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RunOptions mirrors the ifacer command-line flags so callers embedding
+// this package (or testing it) don't have to go through flag parsing.
+type RunOptions struct {
+	// File is the Go source file to parse.
+	File string
+
+	// Ifaces is the comma-separated list of interface names to generate
+	// a decorator for.
+	Ifaces string
+
+	// Out is the destination file for the generated source; "" writes
+	// to stdout.
+	Out string
+
+	// FlattenEmbeds inlines methods pulled in from embedded interfaces
+	// declared in the same file instead of leaving them as embeds.
+	FlattenEmbeds bool
+
+	// TypeParams forwards a generic interface's type parameters through
+	// to the generated New<Iface>Decorator constructor.
+	TypeParams bool
+}
+
+// ParseFlags builds RunOptions from command-line-style args, e.g. the
+// tail of os.Args. It's split out from main so it's testable without a
+// process boundary.
+func ParseFlags(args []string) (RunOptions, error) {
+	fs := flag.NewFlagSet("ifacer", flag.ContinueOnError)
+
+	file := fs.String("file", "", "Go source file containing the interfaces to generate")
+	ifaces := fs.String("iface", "", "comma-separated interface names to generate a decorator for")
+	out := fs.String("out", "", "destination file for the generated source (default: stdout)")
+	flattenEmbeds := fs.Bool("flatten-embeds", false, "inline methods from embedded interfaces declared in the same file")
+	typeParams := fs.Bool("type-params", false, "forward a generic interface's type parameters to the generated constructor")
+
+	if err := fs.Parse(args); err != nil {
+		return RunOptions{}, err
+	}
+
+	if *file == "" || *ifaces == "" {
+		return RunOptions{}, fmt.Errorf("ifacer: -file and -iface are required")
+	}
+
+	return RunOptions{
+		File:          *file,
+		Ifaces:        *ifaces,
+		Out:           *out,
+		FlattenEmbeds: *flattenEmbeds,
+		TypeParams:    *typeParams,
+	}, nil
+}
+
+// Run parses opts.File for opts.Ifaces and writes a generated decorator
+// for each to opts.Out (stdout when unset).
+func Run(opts RunOptions) error {
+	parser := NewParser()
+	parser.FlattenEmbeds = opts.FlattenEmbeds
+
+	names := strings.Split(opts.Ifaces, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+
+	ifaces, err := parser.Parse(opts.File, names)
+	if err != nil {
+		return err
+	}
+
+	generator := NewGenerator()
+	generator.TypeParams = opts.TypeParams
+
+	src, err := generator.Generate(parser.PackageName(), ifaces, parser.Imports())
+	if err != nil {
+		return err
+	}
+
+	if opts.Out == "" {
+		fmt.Println(string(src))
+		return nil
+	}
+
+	return os.WriteFile(opts.Out, src, 0o644)
+}
@@ -0,0 +1,263 @@
+package ifacer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+)
+
+// TypeParam is one entry of a generic interface's type parameter list,
+// e.g. "T" with constraint "comparable" in Repo[T comparable].
+type TypeParam struct {
+	Name string
+
+	Constraint string
+}
+
+// Method is a single interface method, already rendered to source-ready
+// parameter/result strings so the generator doesn't need to re-walk the
+// AST to print a signature.
+type Method struct {
+	Name string
+
+	Params string
+
+	Results string
+
+	// Args is the comma-separated argument names to pass when forwarding
+	// a call to another implementation of the same method, e.g. "ctx,
+	// found" for Iface2(ctx context.Context, found *bool). A variadic
+	// trailing param is passed through with "...".
+	Args string
+
+	// Void is true for a method with no return values, e.g. Iface9(TestStruct,
+	// []TestStruct, [3]TestStruct). The generator must emit a bare forwarding
+	// call for these instead of "return d.Next.M(...)".
+	Void bool
+}
+
+// Iface is everything the generator needs to emit a decorator for one
+// interface: its own methods plus - once flattened - every method it
+// picked up from embedded interfaces. When embeds aren't flattened,
+// Embeds carries the type expression of each one instead (e.g. "Close",
+// or "io.Closer"), so the generator can embed it anonymously in the
+// decorator struct and let Go's method promotion satisfy the interface,
+// rather than dropping it and producing a decorator that doesn't compile.
+type Iface struct {
+	Name string
+
+	TypeParams []TypeParam
+
+	Methods []Method
+
+	Embeds []string
+}
+
+// Import is one of the parsed file's import declarations, reduced to the
+// local identifier a generated method signature would reference it by.
+type Import struct {
+	// Name is the identifier code in this file uses to refer to the
+	// package: the explicit alias if there is one, otherwise the last
+	// path element (the common convention, but not guaranteed - a
+	// package whose declared name differs from its import path's last
+	// element won't be picked up without re-parsing the dependency,
+	// which is outside this tool's single-file scope).
+	Name string
+
+	Path string
+}
+
+// Parser reads a single Go source file and extracts the requested
+// interface declarations. It resolves embedded interfaces by name within
+// the same file; an embed that isn't declared in this file is left as a
+// plain embedded field instead of being flattened (see Parse's doc
+// comment for why this tool intentionally stays single-file).
+type Parser struct {
+	FlattenEmbeds bool
+
+	fset *token.FileSet
+
+	packageName string
+
+	imports []Import
+
+	ifaceSpecs map[string]*ast.InterfaceType
+
+	typeParams map[string][]TypeParam
+}
+
+func NewParser() *Parser {
+	return &Parser{
+		fset:       token.NewFileSet(),
+		ifaceSpecs: make(map[string]*ast.InterfaceType),
+		typeParams: make(map[string][]TypeParam),
+	}
+}
+
+// Parse loads file and returns the requested interfaces by name. It's
+// deliberately single-file (not go/packages-based) so it has no module
+// resolution dependency - same trade-off the original generator made for
+// example1.Test.
+func (p *Parser) Parse(file string, names []string) ([]*Iface, error) {
+	f, err := parser.ParseFile(p.fset, file, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("ifacer: parse %s: %w", file, err)
+	}
+
+	p.packageName = f.Name.Name
+	p.imports = nil
+
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		var name string
+
+		switch {
+		case spec.Name == nil:
+			name = path.Base(importPath)
+		case spec.Name.Name == "_" || spec.Name.Name == ".":
+			continue
+		default:
+			name = spec.Name.Name
+		}
+
+		p.imports = append(p.imports, Import{Name: name, Path: importPath})
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		typeSpec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+
+		ifaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+		if !ok {
+			return true
+		}
+
+		p.ifaceSpecs[typeSpec.Name.Name] = ifaceType
+		p.typeParams[typeSpec.Name.Name] = p.parseTypeParams(typeSpec)
+
+		return true
+	})
+
+	ifaces := make([]*Iface, 0, len(names))
+
+	for _, name := range names {
+		ifaceType, ok := p.ifaceSpecs[name]
+		if !ok {
+			return nil, fmt.Errorf("ifacer: interface %s not found in %s", name, file)
+		}
+
+		iface := &Iface{
+			Name:       name,
+			TypeParams: p.typeParams[name],
+		}
+		iface.Methods, iface.Embeds = p.collectMethods(ifaceType, map[string]bool{}, map[string]bool{})
+
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces, nil
+}
+
+// PackageName returns the package name of the last file passed to Parse.
+func (p *Parser) PackageName() string {
+	return p.packageName
+}
+
+// Imports returns the last file's non-blank, non-dot imports.
+func (p *Parser) Imports() []Import {
+	return p.imports
+}
+
+func (p *Parser) parseTypeParams(typeSpec *ast.TypeSpec) []TypeParam {
+	if typeSpec.TypeParams == nil {
+		return nil
+	}
+
+	var params []TypeParam
+
+	for _, field := range typeSpec.TypeParams.List {
+		constraint := p.exprString(field.Type)
+
+		for _, name := range field.Names {
+			params = append(params, TypeParam{Name: name.Name, Constraint: constraint})
+		}
+	}
+
+	return params
+}
+
+// collectMethods walks ifaceType's method set, recursing into embedded
+// interfaces declared in the same file when FlattenEmbeds is set; when
+// it's not set, each embed is instead recorded in the returned embeds
+// slice so the caller can still produce a compiling decorator (see
+// Iface.Embeds). seenMethods/seenEmbeds de-dupe entries that reach the
+// result via more than one embed path.
+func (p *Parser) collectMethods(ifaceType *ast.InterfaceType, seenMethods, seenEmbeds map[string]bool) (methods []Method, embeds []string) {
+	for _, field := range ifaceType.Methods.List {
+		if len(field.Names) > 0 {
+			funcType, ok := field.Type.(*ast.FuncType)
+			if !ok {
+				continue
+			}
+
+			name := field.Names[0].Name
+			if seenMethods[name] {
+				continue
+			}
+			seenMethods[name] = true
+
+			params, args := p.fieldListParams(funcType.Params)
+
+			methods = append(methods, Method{
+				Name:    name,
+				Params:  params,
+				Results: p.fieldListString(funcType.Results),
+				Args:    args,
+				Void:    funcType.Results == nil || len(funcType.Results.List) == 0,
+			})
+
+			continue
+		}
+
+		// An embedded interface: field.Type is the embedded type's name.
+		embedName := p.exprString(field.Type)
+
+		if !p.FlattenEmbeds {
+			if !seenEmbeds[embedName] {
+				seenEmbeds[embedName] = true
+				embeds = append(embeds, embedName)
+			}
+
+			continue
+		}
+
+		embedded, ok := p.ifaceSpecs[embedName]
+		if !ok {
+			// Declared in another package/file - nothing we can flatten,
+			// so fall back to embedding it instead of silently dropping
+			// its methods (which would leave the decorator not
+			// implementing the interface it's meant to decorate).
+			if !seenEmbeds[embedName] {
+				seenEmbeds[embedName] = true
+				embeds = append(embeds, embedName)
+			}
+
+			continue
+		}
+
+		embeddedMethods, embeddedEmbeds := p.collectMethods(embedded, seenMethods, seenEmbeds)
+		methods = append(methods, embeddedMethods...)
+		embeds = append(embeds, embeddedEmbeds...)
+	}
+
+	return methods, embeds
+}
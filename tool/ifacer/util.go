@@ -0,0 +1,108 @@
+package ifacer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+)
+
+// exprString renders an AST expression (a type, a constraint, ...) back
+// to the source text it came from.
+func (p *Parser) exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, p.fset, expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+
+	return buf.String()
+}
+
+// fieldListString renders a *ast.FieldList (a method's params or results)
+// as a comma-separated parameter list suitable for splicing into a
+// generated func signature, e.g. "a string, b ...int".
+func (p *Parser) fieldListString(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(fields.List))
+
+	for _, field := range fields.List {
+		typeStr := p.exprString(field.Type)
+
+		if len(field.Names) == 0 {
+			parts = append(parts, typeStr)
+			continue
+		}
+
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+
+		parts = append(parts, fmt.Sprintf("%s %s", joinComma(names), typeStr))
+	}
+
+	return joinComma(parts)
+}
+
+// fieldListParams renders fields as a comma-separated parameter list
+// ("a, b string") suitable for a generated func signature, together with
+// the comma-separated argument names to use when forwarding a call to
+// another implementation of the same method ("a, b"). Every param is
+// given a name even if the interface declared it anonymously (an
+// anonymous param can't be referenced, so "_" can't be used as a call
+// argument) by synthesizing "argN" from its position in the field list.
+// A variadic trailing param (field.Type is *ast.Ellipsis) is forwarded
+// with "..." so the call stays valid.
+func (p *Parser) fieldListParams(fields *ast.FieldList) (params string, args string) {
+	if fields == nil {
+		return "", ""
+	}
+
+	var paramParts, argParts []string
+
+	argIndex := 0
+
+	for _, field := range fields.List {
+		typeStr := p.exprString(field.Type)
+		_, variadic := field.Type.(*ast.Ellipsis)
+
+		names := make([]string, 0, len(field.Names))
+		if len(field.Names) == 0 {
+			names = append(names, fmt.Sprintf("arg%d", argIndex))
+			argIndex++
+		} else {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+				argIndex++
+			}
+		}
+
+		paramParts = append(paramParts, fmt.Sprintf("%s %s", joinComma(names), typeStr))
+
+		for _, name := range names {
+			arg := name
+			if variadic {
+				arg += "..."
+			}
+
+			argParts = append(argParts, arg)
+		}
+	}
+
+	return joinComma(paramParts), joinComma(argParts)
+}
+
+func joinComma(parts []string) string {
+	out := ""
+	for i, part := range parts {
+		if i > 0 {
+			out += ", "
+		}
+		out += part
+	}
+
+	return out
+}